@@ -0,0 +1,99 @@
+package pbloom
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"math/bits"
+)
+
+// compatible reports whether f and other were built with matching
+// parameters (same k and same bit array length), the precondition for
+// Union, Intersect, and Equal to be meaningful.
+func (f *Filter) compatible(other *Filter) error {
+	if other == nil {
+		return errors.New("other filter is nil")
+	}
+	if f.k != other.k {
+		return errors.New("filters have different numbers of hash functions (k)")
+	}
+	if len(f.bits) != len(other.bits) {
+		return errors.New("filters have different bit array sizes")
+	}
+	return nil
+}
+
+// Union ORs other's bit array into f in place, so that afterwards f
+// represents the union of the two filters' sets. Both filters must have
+// been built with identical (entries, size) or (entries, fp) parameters,
+// i.e. the same k and bit array length; otherwise an error is returned. The
+// resulting filter's false positive rate is the combined rate of the two
+// source filters.
+func (f *Filter) Union(other *Filter) error {
+	if err := f.compatible(other); err != nil {
+		return err
+	}
+	for i := range f.bits {
+		f.bits[i] |= other.bits[i]
+	}
+	return nil
+}
+
+// Intersect ANDs other's bit array into f in place, approximating the
+// intersection of the two filters' sets. This can introduce false
+// positives beyond the nominal rate of either source filter, since a bit
+// set by unrelated keys in both filters will look like a shared key. Both
+// filters must have been built with identical (entries, size) or (entries,
+// fp) parameters; otherwise an error is returned.
+func (f *Filter) Intersect(other *Filter) error {
+	if err := f.compatible(other); err != nil {
+		return err
+	}
+	for i := range f.bits {
+		f.bits[i] &= other.bits[i]
+	}
+	return nil
+}
+
+// Equal reports whether f and other have the same parameters and bit
+// array contents.
+func (f *Filter) Equal(other *Filter) bool {
+	if other == nil {
+		return false
+	}
+	return f.k == other.k && bytes.Equal(f.bits, other.bits)
+}
+
+// Copy returns a deep copy of f, suitable for non-destructive use with
+// Union and Intersect.
+func (f *Filter) Copy() *Filter {
+	bits := make([]byte, len(f.bits))
+	copy(bits, f.bits)
+	return &Filter{
+		bits: bits,
+		k:    f.k,
+	}
+}
+
+// PopCount returns the number of set bits in f's bit array.
+func (f *Filter) PopCount() int {
+	count := 0
+	for _, b := range f.bits {
+		count += bits.OnesCount8(b)
+	}
+	return count
+}
+
+// EstimateCardinality estimates the number of distinct keys that have been
+// inserted into f, using the Swamidass & Baldi estimator
+// n ≈ -(m/k) * ln(1 - X/m), where m is the number of bits, k is the number
+// of hash functions, and X is the number of set bits.
+func (f *Filter) EstimateCardinality() float64 {
+	m := float64(len(f.bits) * 8)
+	k := float64(f.k)
+	x := float64(f.PopCount())
+	if x >= m {
+		return math.Inf(1)
+	}
+	return -(m / k) * math.Log(1-x/m)
+}
@@ -0,0 +1,111 @@
+package pbloom
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFilterUnion tests that Union reports the union of two filters' sets.
+func TestFilterUnion(t *testing.T) {
+	a, err := NewFilterFromEntriesAndFP(1000, 0.01)
+	assert.NoError(t, err)
+	b, err := NewFilterFromEntriesAndFP(1000, 0.01)
+	assert.NoError(t, err)
+
+	a.Put([]byte("apple"))
+	b.Put([]byte("banana"))
+
+	assert.NoError(t, a.Union(b))
+	assert.True(t, a.Exists([]byte("apple")))
+	assert.True(t, a.Exists([]byte("banana")))
+}
+
+// TestFilterIntersect tests that Intersect approximates set intersection.
+func TestFilterIntersect(t *testing.T) {
+	a, err := NewFilterFromEntriesAndFP(1000, 0.01)
+	assert.NoError(t, err)
+	b, err := NewFilterFromEntriesAndFP(1000, 0.01)
+	assert.NoError(t, err)
+
+	shared := "shared-key"
+	a.Put([]byte(shared))
+	a.Put([]byte("only-in-a"))
+	b.Put([]byte(shared))
+	b.Put([]byte("only-in-b"))
+
+	assert.NoError(t, a.Intersect(b))
+	assert.True(t, a.Exists([]byte(shared)))
+}
+
+// TestFilterUnionIntersectIncompatible tests that mismatched filters are rejected.
+func TestFilterUnionIntersectIncompatible(t *testing.T) {
+	a, err := NewFilterFromEntriesAndFP(1000, 0.01)
+	assert.NoError(t, err)
+	b, err := NewFilterFromEntriesAndFP(500, 0.05)
+	assert.NoError(t, err)
+
+	assert.Error(t, a.Union(b))
+	assert.Error(t, a.Intersect(b))
+}
+
+// TestFilterUnionIntersectNil tests that a nil other filter returns an
+// error instead of panicking.
+func TestFilterUnionIntersectNil(t *testing.T) {
+	a, err := NewFilterFromEntriesAndFP(1000, 0.01)
+	assert.NoError(t, err)
+
+	assert.Error(t, a.Union(nil))
+	assert.Error(t, a.Intersect(nil))
+}
+
+// TestFilterEqual tests the Equal method.
+func TestFilterEqual(t *testing.T) {
+	a, err := NewFilterFromEntriesAndFP(1000, 0.01)
+	assert.NoError(t, err)
+	a.Put([]byte("apple"))
+
+	b := a.Copy()
+	assert.True(t, a.Equal(b))
+
+	b.Put([]byte("banana"))
+	assert.False(t, a.Equal(b))
+}
+
+// TestFilterCopy tests that Copy produces an independent filter.
+func TestFilterCopy(t *testing.T) {
+	a, err := NewFilterFromEntriesAndFP(1000, 0.01)
+	assert.NoError(t, err)
+	a.Put([]byte("apple"))
+
+	b := a.Copy()
+	b.Put([]byte("banana"))
+
+	assert.True(t, a.Exists([]byte("apple")))
+	assert.False(t, a.Exists([]byte("banana")))
+	assert.True(t, b.Exists([]byte("banana")))
+}
+
+// TestFilterPopCountAndEstimateCardinality tests PopCount and
+// EstimateCardinality against a filter with a known number of inserted keys.
+func TestFilterPopCountAndEstimateCardinality(t *testing.T) {
+	entries := 1000
+	filter, err := NewFilterFromEntriesAndFP(entries, 0.01)
+	assert.NoError(t, err)
+
+	for i := 0; i < entries; i++ {
+		filter.Put(algebraTestKey(i))
+	}
+
+	assert.Greater(t, filter.PopCount(), 0)
+
+	estimate := filter.EstimateCardinality()
+	// The estimator should be within 10% of the true cardinality for a
+	// filter sized with a reasonable false positive rate.
+	assert.InDelta(t, float64(entries), estimate, float64(entries)*0.1)
+}
+
+func algebraTestKey(i int) []byte {
+	return []byte("algebra_key_" + strconv.Itoa(i))
+}
@@ -0,0 +1,224 @@
+package pbloom
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"math/bits"
+)
+
+// binaryMagic identifies a pbloom binary-format payload. It appears as the
+// first 4 bytes of every MarshalBinary output.
+var binaryMagic = [4]byte{'P', 'B', 'L', 'M'}
+
+// binaryFormatVersion is the version of the binary header layout written by
+// MarshalBinary. UnmarshalBinary rejects any other version.
+const binaryFormatVersion = 1
+
+// binaryHeaderSize is the size, in bytes, of everything in a binary-format
+// payload before the raw bit array: magic(4) + version(1) + flags(1) + k(1)
+// + m(4) + crc32c(4).
+const binaryHeaderSize = 4 + 1 + 1 + 1 + 4 + 4
+
+// binaryFilterKind identifies the concrete filter type encoded in a binary
+// payload's flags byte (low nibble).
+type binaryFilterKind uint8
+
+const (
+	binaryKindPlain binaryFilterKind = iota
+	binaryKindBlocked
+	binaryKindCounting
+	binaryKindScalable
+	binaryKindConcurrent
+)
+
+// binaryHashFamily identifies the hash function family used to build the
+// filter (high nibble of the flags byte). pbloom currently only ships the
+// murmur3 x64-128 family.
+type binaryHashFamily uint8
+
+const binaryHashMurmur3X64128 binaryHashFamily = iota
+
+func makeBinaryFlags(kind binaryFilterKind, hashFamily binaryHashFamily) byte {
+	return byte(kind) | byte(hashFamily)<<4
+}
+
+func splitBinaryFlags(flags byte) (binaryFilterKind, binaryHashFamily) {
+	return binaryFilterKind(flags & 0x0F), binaryHashFamily(flags >> 4)
+}
+
+// crc32cPayload returns the CRC32 checksum of data using the Castagnoli
+// polynomial, the checksum algorithm used throughout the binary format.
+func crc32cPayload(data []byte) uint32 {
+	return crc32.Checksum(data, crc32.MakeTable(crc32.Castagnoli))
+}
+
+// MarshalBinary encodes the Filter into pbloom's compact, self-describing
+// binary format: a 4-byte magic "PBLM", a 1-byte version, a 1-byte flags
+// field (filter kind and hash family), a 1-byte k, a 4-byte big-endian m
+// (bit count), a 4-byte big-endian CRC32C of the bit array, and finally the
+// raw bit array itself. Unlike Serialize, this format is not msgpack and is
+// intended to be read directly by non-Go consumers such as the pbloompg
+// PostgreSQL extension; see BINARY_FORMAT.md for the full layout.
+func (f *Filter) MarshalBinary() ([]byte, error) {
+	m := uint32(len(f.bits)) * 8
+
+	out := make([]byte, binaryHeaderSize+len(f.bits))
+	copy(out[0:4], binaryMagic[:])
+	out[4] = binaryFormatVersion
+	out[5] = makeBinaryFlags(binaryKindPlain, binaryHashMurmur3X64128)
+	out[6] = f.k
+	binary.BigEndian.PutUint32(out[7:11], m)
+	binary.BigEndian.PutUint32(out[11:15], crc32cPayload(f.bits))
+	copy(out[binaryHeaderSize:], f.bits)
+	return out, nil
+}
+
+// UnmarshalBinary decodes a Filter previously encoded with MarshalBinary,
+// validating the magic, version, flags (must be a plain filter built with
+// murmur3 x64-128), and CRC32C of the bit array.
+func (f *Filter) UnmarshalBinary(data []byte) error {
+	if len(data) < binaryHeaderSize {
+		return fmt.Errorf("binary payload too short: got %d bytes, need at least %d", len(data), binaryHeaderSize)
+	}
+	if [4]byte(data[0:4]) != binaryMagic {
+		return fmt.Errorf("bad magic %q, expected %q", data[0:4], binaryMagic[:])
+	}
+	version := data[4]
+	if version != binaryFormatVersion {
+		return fmt.Errorf("unsupported binary format version %d", version)
+	}
+	kind, hashFamily := splitBinaryFlags(data[5])
+	if kind != binaryKindPlain {
+		return fmt.Errorf("binary payload is not a plain Filter (kind %d)", kind)
+	}
+	if hashFamily != binaryHashMurmur3X64128 {
+		return fmt.Errorf("unsupported hash family %d", hashFamily)
+	}
+	k := data[6]
+	m := binary.BigEndian.Uint32(data[7:11])
+	wantCRC := binary.BigEndian.Uint32(data[11:15])
+
+	bits := data[binaryHeaderSize:]
+	if uint32(len(bits))*8 != m {
+		return fmt.Errorf("bit array length %d does not match header m=%d", len(bits), m)
+	}
+	if gotCRC := crc32cPayload(bits); gotCRC != wantCRC {
+		return fmt.Errorf("CRC32C mismatch: header says %08x, computed %08x", wantCRC, gotCRC)
+	}
+
+	bitsCopy := make([]byte, len(bits))
+	copy(bitsCopy, bits)
+	f.bits = bitsCopy
+	f.k = k
+	return nil
+}
+
+// binaryHeader is a parsed (but not bit-array-carrying) view of a binary
+// payload's header, used by tooling like the "pbloom inspect" CLI command
+// that wants to report on a file without fully decoding it into a Filter.
+type binaryHeader struct {
+	Version    uint8
+	Kind       binaryFilterKind
+	HashFamily binaryHashFamily
+	K          uint8
+	M          uint32
+	CRC32C     uint32
+}
+
+// parseBinaryHeader parses the fixed-size header of a binary payload. It
+// checks only the payload length and the magic; the version and flags are
+// returned as found, and the CRC32C is not checked against the trailing bit
+// array.
+func parseBinaryHeader(data []byte) (binaryHeader, error) {
+	if len(data) < binaryHeaderSize {
+		return binaryHeader{}, fmt.Errorf("binary payload too short: got %d bytes, need at least %d", len(data), binaryHeaderSize)
+	}
+	if [4]byte(data[0:4]) != binaryMagic {
+		return binaryHeader{}, fmt.Errorf("bad magic %q, expected %q", data[0:4], binaryMagic[:])
+	}
+	kind, hashFamily := splitBinaryFlags(data[5])
+	return binaryHeader{
+		Version:    data[4],
+		Kind:       kind,
+		HashFamily: hashFamily,
+		K:          data[6],
+		M:          binary.BigEndian.Uint32(data[7:11]),
+		CRC32C:     binary.BigEndian.Uint32(data[11:15]),
+	}, nil
+}
+
+// String renders a binaryFilterKind the way operator tooling (e.g. the
+// "pbloom inspect" CLI command) should display it.
+func (k binaryFilterKind) String() string {
+	switch k {
+	case binaryKindPlain:
+		return "plain"
+	case binaryKindBlocked:
+		return "blocked"
+	case binaryKindCounting:
+		return "counting"
+	case binaryKindScalable:
+		return "scalable"
+	case binaryKindConcurrent:
+		return "concurrent"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(k))
+	}
+}
+
+// String renders a binaryHashFamily the way operator tooling should display it.
+func (h binaryHashFamily) String() string {
+	switch h {
+	case binaryHashMurmur3X64128:
+		return "murmur3-x64-128"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(h))
+	}
+}
+
+// BinaryInfo summarizes a binary-format payload's header for tooling, such
+// as the "pbloom inspect" CLI command, without requiring the caller to
+// decode it into a concrete filter type.
+type BinaryInfo struct {
+	Version    uint8
+	Kind       string
+	HashFamily string
+	K          uint8
+	M          uint32
+	CRC32C     uint32
+	CRCValid   bool
+	FillRatio  float64
+}
+
+// InspectBinary parses a binary-format payload's header and reports its
+// fields along with the bit array's fill ratio and whether its CRC32C
+// checksum is valid, without requiring the caller to know the concrete
+// filter kind ahead of time.
+func InspectBinary(data []byte) (BinaryInfo, error) {
+	header, err := parseBinaryHeader(data)
+	if err != nil {
+		return BinaryInfo{}, err
+	}
+
+	bitsData := data[binaryHeaderSize:]
+	set := 0
+	for _, b := range bitsData {
+		set += bits.OnesCount8(b)
+	}
+	fillRatio := 0.0
+	if header.M > 0 {
+		fillRatio = float64(set) / float64(header.M)
+	}
+
+	return BinaryInfo{
+		Version:    header.Version,
+		Kind:       header.Kind.String(),
+		HashFamily: header.HashFamily.String(),
+		K:          header.K,
+		M:          header.M,
+		CRC32C:     header.CRC32C,
+		CRCValid:   crc32cPayload(bitsData) == header.CRC32C,
+		FillRatio:  fillRatio,
+	}, nil
+}
@@ -0,0 +1,145 @@
+package pbloom
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestFilterMarshalUnmarshalBinary tests the MarshalBinary/UnmarshalBinary round trip.
+func TestFilterMarshalUnmarshalBinary(t *testing.T) {
+	filter, err := NewFilterFromEntriesAndFP(1000, 0.01)
+	assert.NoError(t, err)
+
+	keys := []string{"alpha", "beta", "gamma"}
+	for _, key := range keys {
+		filter.Put([]byte(key))
+	}
+
+	data, err := filter.MarshalBinary()
+	assert.NoError(t, err)
+	assert.Equal(t, "PBLM", string(data[0:4]))
+
+	var decoded Filter
+	assert.NoError(t, decoded.UnmarshalBinary(data))
+	assert.Equal(t, filter.k, decoded.k)
+	assert.Equal(t, filter.bits, decoded.bits)
+
+	for _, key := range keys {
+		assert.True(t, decoded.Exists([]byte(key)))
+	}
+}
+
+// TestFilterUnmarshalBinaryRejectsCorruption tests that UnmarshalBinary
+// rejects a bad magic, an unsupported version, and a corrupted payload.
+func TestFilterUnmarshalBinaryRejectsCorruption(t *testing.T) {
+	filter, err := NewFilterFromEntriesAndFP(1000, 0.01)
+	assert.NoError(t, err)
+	filter.Put([]byte("apple"))
+
+	data, err := filter.MarshalBinary()
+	assert.NoError(t, err)
+
+	t.Run("BadMagic", func(t *testing.T) {
+		corrupted := append([]byte(nil), data...)
+		corrupted[0] = 'X'
+		var decoded Filter
+		assert.Error(t, decoded.UnmarshalBinary(corrupted))
+	})
+
+	t.Run("BadVersion", func(t *testing.T) {
+		corrupted := append([]byte(nil), data...)
+		corrupted[4] = 99
+		var decoded Filter
+		assert.Error(t, decoded.UnmarshalBinary(corrupted))
+	})
+
+	t.Run("CorruptedBits", func(t *testing.T) {
+		corrupted := append([]byte(nil), data...)
+		corrupted[len(corrupted)-1] ^= 0xFF
+		var decoded Filter
+		assert.Error(t, decoded.UnmarshalBinary(corrupted))
+	})
+
+	t.Run("TooShort", func(t *testing.T) {
+		var decoded Filter
+		assert.Error(t, decoded.UnmarshalBinary(data[:binaryHeaderSize-1]))
+	})
+}
+
+// TestInspectBinary tests that InspectBinary reports the header fields, a
+// sane fill ratio, and a valid CRC32C for an uncorrupted payload.
+func TestInspectBinary(t *testing.T) {
+	entries := 1000
+	filter, err := NewFilterFromEntriesAndFP(entries, 0.01)
+	assert.NoError(t, err)
+	for i := 0; i < entries; i++ {
+		filter.Put(binaryTestKey(i))
+	}
+
+	data, err := filter.MarshalBinary()
+	assert.NoError(t, err)
+
+	info, err := InspectBinary(data)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(binaryFormatVersion), info.Version)
+	assert.Equal(t, "plain", info.Kind)
+	assert.Equal(t, "murmur3-x64-128", info.HashFamily)
+	assert.Equal(t, filter.k, info.K)
+	assert.Equal(t, uint32(len(filter.bits)*8), info.M)
+	assert.True(t, info.CRCValid)
+	assert.Greater(t, info.FillRatio, 0.0)
+	assert.Less(t, info.FillRatio, 1.0)
+}
+
+// TestInspectBinaryCorruptedCRC tests that flipping a bit in the payload
+// after the header is caught by CRCValid, without InspectBinary itself
+// erroring out (the header is still well-formed).
+func TestInspectBinaryCorruptedCRC(t *testing.T) {
+	filter, err := NewFilterFromEntriesAndFP(1000, 0.01)
+	assert.NoError(t, err)
+	filter.Put([]byte("apple"))
+
+	data, err := filter.MarshalBinary()
+	assert.NoError(t, err)
+
+	corrupted := append([]byte(nil), data...)
+	corrupted[len(corrupted)-1] ^= 0xFF
+
+	info, err := InspectBinary(corrupted)
+	assert.NoError(t, err)
+	assert.False(t, info.CRCValid)
+}
+
+// TestInspectBinaryTruncated tests that InspectBinary errors out on a
+// payload too short to contain a full header.
+func TestInspectBinaryTruncated(t *testing.T) {
+	filter, err := NewFilterFromEntriesAndFP(1000, 0.01)
+	assert.NoError(t, err)
+	filter.Put([]byte("apple"))
+
+	data, err := filter.MarshalBinary()
+	assert.NoError(t, err)
+
+	_, err = InspectBinary(data[:binaryHeaderSize-1])
+	assert.Error(t, err)
+}
+
+// TestBinaryFilterKindAndHashFamilyString tests the String() renderings
+// used by InspectBinary and the "pbloom inspect" CLI command.
+func TestBinaryFilterKindAndHashFamilyString(t *testing.T) {
+	assert.Equal(t, "plain", binaryKindPlain.String())
+	assert.Equal(t, "blocked", binaryKindBlocked.String())
+	assert.Equal(t, "counting", binaryKindCounting.String())
+	assert.Equal(t, "scalable", binaryKindScalable.String())
+	assert.Equal(t, "concurrent", binaryKindConcurrent.String())
+	assert.Equal(t, "unknown(99)", binaryFilterKind(99).String())
+
+	assert.Equal(t, "murmur3-x64-128", binaryHashMurmur3X64128.String())
+	assert.Equal(t, "unknown(9)", binaryHashFamily(9).String())
+}
+
+func binaryTestKey(i int) []byte {
+	return []byte("binary_key_" + strconv.Itoa(i))
+}
@@ -0,0 +1,167 @@
+package pbloom
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+
+	"github.com/twmb/murmur3"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// blockSizeBits is the size, in bits, of a single block. It defaults to the
+// width of a typical CPU cache line (512 bits = 64 bytes) so that every
+// lookup touches exactly one cache line.
+const blockSizeBits = 512
+
+// blockFPPenalty compensates for the higher false positive rate of a
+// blocked filter relative to an equivalently sized non-blocked filter: each
+// block sees a smaller, noisier slice of the key space, so we size the
+// filter ~12% larger than the naive formula to keep the observed FP rate
+// close to the requested one.
+const blockFPPenalty = 1.12
+
+// BlockedFilter is a cache-aware Bloom filter. Its bit array is split into
+// fixed-size blocks (see blockSizeBits) and every key is mapped to exactly
+// one block, so Put and Exists each touch only a single block instead of
+// scattering reads/writes across the whole bit array. This is the layout
+// used by Pebble/LevelDB sstable filters and is significantly faster to
+// query than a plain Filter at the cost of a slightly higher false
+// positive rate for a given size.
+type BlockedFilter struct {
+	bits      []byte
+	k         uint8
+	numBlocks uint64
+}
+
+// NewBlockedFilterFromEntriesAndFP initializes a BlockedFilter sized for the
+// given number of entries and desired false positive rate. The underlying
+// bit array is rounded up to a whole number of blockSizeBits-sized blocks
+// and inflated by blockFPPenalty to offset the per-block false positive
+// rate being worse than the global rate of an equivalent plain Filter.
+func NewBlockedFilterFromEntriesAndFP(entries int, fpRate float64) (*BlockedFilter, error) {
+	if entries <= 0 {
+		return nil, errors.New("number of entries must be positive")
+	}
+	if fpRate <= 0 || fpRate >= 1 {
+		return nil, errors.New("false positive rate must be between 0 and 1")
+	}
+
+	// Base bit count for the requested false positive rate, inflated to
+	// compensate for the per-block penalty.
+	m := -float64(entries) * math.Log(fpRate) / (math.Pow(math.Log(2), 2))
+	m *= blockFPPenalty
+
+	numBlocks := uint64(math.Ceil(m / blockSizeBits))
+	if numBlocks == 0 {
+		numBlocks = 1
+	}
+	m = float64(numBlocks) * blockSizeBits
+
+	k := math.Round((m / float64(entries)) * math.Log(2))
+
+	return &BlockedFilter{
+		bits:      make([]byte, numBlocks*(blockSizeBits/8)),
+		k:         uint8(k),
+		numBlocks: numBlocks,
+	}, nil
+}
+
+// blockFor computes the block index and the two hash halves used to derive
+// the within-block bit positions for key. Hashing uses a fresh, stateless
+// murmur3.Sum128 call per key rather than a shared hasher instance, so two
+// goroutines calling Put/Exists don't race on hasher state the way they
+// would with a single shared murmur3.Hash128 (the plain []byte bit array
+// itself is still not safe for concurrent writes; use ConcurrentFilter for
+// that).
+func (f *BlockedFilter) blockFor(key []byte) (blockIdx uint64, h1, h2 uint64) {
+	h1, h2 = murmur3.Sum128(key)
+	// Lemire's fast range reduction: maps h1 uniformly into [0, numBlocks).
+	blockIdx, _ = bits.Mul64(h1, f.numBlocks)
+	return blockIdx, h1, h2
+}
+
+// Put inserts a key into the BlockedFilter, touching only the single block
+// the key hashes to.
+func (f *BlockedFilter) Put(key []byte) {
+	blockIdx, h1, h2 := f.blockFor(key)
+	block := f.bits[blockIdx*(blockSizeBits/8) : (blockIdx+1)*(blockSizeBits/8)]
+	for i := uint64(0); i < uint64(f.k); i++ {
+		pos := (h1 + i*h2) & (blockSizeBits - 1)
+		block[pos/8] |= 1 << (pos % 8)
+	}
+}
+
+// Exists checks whether a key is possibly in the BlockedFilter, touching
+// only the single block the key hashes to.
+func (f *BlockedFilter) Exists(key []byte) bool {
+	blockIdx, h1, h2 := f.blockFor(key)
+	block := f.bits[blockIdx*(blockSizeBits/8) : (blockIdx+1)*(blockSizeBits/8)]
+	for i := uint64(0); i < uint64(f.k); i++ {
+		pos := (h1 + i*h2) & (blockSizeBits - 1)
+		if block[pos/8]&(1<<(pos%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Serialize serializes the BlockedFilter into a byte slice using
+// MessagePack, tagged with kindBlocked so FromSerializedBlocked can
+// recognize it.
+func (f *BlockedFilter) Serialize() ([]byte, error) {
+	encoded := bytes.Buffer{}
+	enc := msgpack.NewEncoder(&encoded)
+	if err := enc.EncodeUint8(uint8(kindBlocked)); err != nil {
+		return nil, err
+	}
+	if err := enc.EncodeBytes(f.bits); err != nil {
+		return nil, err
+	}
+	if err := enc.EncodeUint8(f.k); err != nil {
+		return nil, err
+	}
+	if err := enc.EncodeUint64(f.numBlocks); err != nil {
+		return nil, err
+	}
+	return encoded.Bytes(), nil
+}
+
+// FromSerializedBlocked deserializes a BlockedFilter previously produced by
+// Serialize. It returns an error if data was tagged as a different filter
+// kind (e.g. a plain Filter serialized via Filter.Serialize).
+func FromSerializedBlocked(data []byte) (*BlockedFilter, error) {
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	kind, err := dec.DecodeUint8()
+	if err != nil {
+		return nil, err
+	}
+	if serializedKind(kind) != kindBlocked {
+		return nil, fmt.Errorf("data is not a serialized BlockedFilter (kind %d)", kind)
+	}
+	bitsData, err := dec.DecodeBytes()
+	if err != nil {
+		return nil, err
+	}
+	k, err := dec.DecodeUint8()
+	if err != nil {
+		return nil, err
+	}
+	numBlocks, err := dec.DecodeUint64()
+	if err != nil {
+		return nil, err
+	}
+	if numBlocks == 0 {
+		return nil, errors.New("numBlocks must be positive")
+	}
+	if uint64(len(bitsData)) != numBlocks*(blockSizeBits/8) {
+		return nil, fmt.Errorf("bit array length %d does not match numBlocks %d", len(bitsData), numBlocks)
+	}
+	return &BlockedFilter{
+		bits:      bitsData,
+		k:         k,
+		numBlocks: numBlocks,
+	}, nil
+}
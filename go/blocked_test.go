@@ -0,0 +1,196 @@
+package pbloom
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// TestNewBlockedFilterFromEntriesAndFP tests the NewBlockedFilterFromEntriesAndFP constructor.
+func TestNewBlockedFilterFromEntriesAndFP(t *testing.T) {
+	tests := []struct {
+		name        string
+		entries     int
+		fpRate      float64
+		expectError bool
+	}{
+		{name: "ValidInput", entries: 1000, fpRate: 0.01, expectError: false},
+		{name: "ZeroEntries", entries: 0, fpRate: 0.01, expectError: true},
+		{name: "NegativeEntries", entries: -10, fpRate: 0.01, expectError: true},
+		{name: "FPRateZero", entries: 1000, fpRate: 0.0, expectError: true},
+		{name: "FPRateOne", entries: 1000, fpRate: 1.0, expectError: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := NewBlockedFilterFromEntriesAndFP(tt.entries, tt.fpRate)
+			if tt.expectError {
+				assert.Error(t, err)
+				assert.Nil(t, filter)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, filter)
+				assert.Equal(t, 0, len(filter.bits)%(blockSizeBits/8), "bit array should be a whole number of blocks")
+			}
+		})
+	}
+}
+
+// TestBlockedFilterPutExists tests the Put and Exists methods of BlockedFilter.
+func TestBlockedFilterPutExists(t *testing.T) {
+	filter, err := NewBlockedFilterFromEntriesAndFP(100, 0.01)
+	assert.NoError(t, err)
+	assert.NotNil(t, filter)
+
+	insertedKeys := []string{"apple", "banana", "cherry", "date", "elderberry"}
+	for _, key := range insertedKeys {
+		filter.Put([]byte(key))
+	}
+
+	for _, key := range insertedKeys {
+		assert.True(t, filter.Exists([]byte(key)), "Expected key %s to exist", key)
+	}
+}
+
+// TestBlockedFilterSerializeAndDeserialize tests the Serialize and FromSerializedBlocked methods.
+func TestBlockedFilterSerializeAndDeserialize(t *testing.T) {
+	filter, err := NewBlockedFilterFromEntriesAndFP(1000, 0.01)
+	assert.NoError(t, err)
+	assert.NotNil(t, filter)
+
+	keys := []string{"alpha", "beta", "gamma", "delta", "epsilon"}
+	for _, key := range keys {
+		filter.Put([]byte(key))
+	}
+
+	data, err := filter.Serialize()
+	assert.NoError(t, err)
+	assert.NotNil(t, data)
+
+	deserialized, err := FromSerializedBlocked(data)
+	assert.NoError(t, err)
+	assert.NotNil(t, deserialized)
+	assert.Equal(t, filter.k, deserialized.k)
+	assert.Equal(t, filter.numBlocks, deserialized.numBlocks)
+	assert.Equal(t, filter.bits, deserialized.bits)
+
+	for _, key := range keys {
+		assert.True(t, deserialized.Exists([]byte(key)), "Key %s should exist in deserialized filter", key)
+	}
+
+	// A plain Filter's payload should be rejected.
+	plain, err := NewFilterFromEntriesAndFP(1000, 0.01)
+	assert.NoError(t, err)
+	plainData, err := plain.Serialize()
+	assert.NoError(t, err)
+	_, err = FromSerializedBlocked(plainData)
+	assert.Error(t, err)
+}
+
+// TestBlockedFilterFromSerializedRejectsMismatchedNumBlocks tests that a
+// payload whose numBlocks doesn't match the decoded bit array length is
+// rejected instead of being accepted and later panicking in Put/Exists.
+func TestBlockedFilterFromSerializedRejectsMismatchedNumBlocks(t *testing.T) {
+	filter, err := NewBlockedFilterFromEntriesAndFP(1000, 0.01)
+	assert.NoError(t, err)
+	filter.Put([]byte("apple"))
+
+	data, err := filter.Serialize()
+	assert.NoError(t, err)
+
+	t.Run("NumBlocksTooLarge", func(t *testing.T) {
+		corrupted := corruptBlockedNumBlocks(t, data, filter.numBlocks*2)
+		_, err := FromSerializedBlocked(corrupted)
+		assert.Error(t, err)
+	})
+
+	t.Run("NumBlocksZero", func(t *testing.T) {
+		corrupted := corruptBlockedNumBlocks(t, data, 0)
+		_, err := FromSerializedBlocked(corrupted)
+		assert.Error(t, err)
+	})
+}
+
+// corruptBlockedNumBlocks decodes a BlockedFilter payload with
+// FromSerializedBlocked and re-encodes it field by field, in Serialize's
+// layout, with numBlocks replaced, so the rest of the payload (bits, k)
+// stays valid.
+func corruptBlockedNumBlocks(t *testing.T, data []byte, numBlocks uint64) []byte {
+	t.Helper()
+	decoded, err := FromSerializedBlocked(data)
+	assert.NoError(t, err)
+	decoded.numBlocks = numBlocks
+
+	encoded := bytes.Buffer{}
+	enc := msgpack.NewEncoder(&encoded)
+	assert.NoError(t, enc.EncodeUint8(uint8(kindBlocked)))
+	assert.NoError(t, enc.EncodeBytes(decoded.bits))
+	assert.NoError(t, enc.EncodeUint8(decoded.k))
+	assert.NoError(t, enc.EncodeUint64(decoded.numBlocks))
+	return encoded.Bytes()
+}
+
+// TestBlockedFilterFalsePositiveRate tests that the observed false positive
+// rate stays within 2x of the requested rate.
+func TestBlockedFilterFalsePositiveRate(t *testing.T) {
+	entries := 2000
+	fpRate := 0.01
+	filter, err := NewBlockedFilterFromEntriesAndFP(entries, fpRate)
+	assert.NoError(t, err)
+	assert.NotNil(t, filter)
+
+	for i := 0; i < entries; i++ {
+		filter.Put(blockedTestKey(i))
+	}
+
+	testSize := 10000
+	falsePositives := 0
+	for i := entries; i < entries+testSize; i++ {
+		if filter.Exists(blockedTestKey(i)) {
+			falsePositives++
+		}
+	}
+
+	actualFPRate := float64(falsePositives) / float64(testSize)
+	assert.LessOrEqual(t, actualFPRate, fpRate*2, "False positive rate should stay within bounds of the target")
+}
+
+func blockedTestKey(i int) []byte {
+	return []byte("blocked_key_" + strconv.Itoa(i))
+}
+
+// BenchmarkFilterExists benchmarks point lookups against the existing plain Filter.
+func BenchmarkFilterExists(b *testing.B) {
+	filter, err := NewFilterFromEntriesAndFP(100000, 0.01)
+	if err != nil {
+		b.Fatal(err)
+	}
+	for i := 0; i < 100000; i++ {
+		filter.Put(blockedTestKey(i))
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		filter.Exists(blockedTestKey(i % 100000))
+	}
+}
+
+// BenchmarkBlockedFilterExists benchmarks point lookups against BlockedFilter.
+func BenchmarkBlockedFilterExists(b *testing.B) {
+	filter, err := NewBlockedFilterFromEntriesAndFP(100000, 0.01)
+	if err != nil {
+		b.Fatal(err)
+	}
+	for i := 0; i < 100000; i++ {
+		filter.Put(blockedTestKey(i))
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		filter.Exists(blockedTestKey(i % 100000))
+	}
+}
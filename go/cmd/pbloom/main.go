@@ -0,0 +1,61 @@
+// Command pbloom is a small operator CLI for inspecting serialized pbloom
+// filters on disk.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	pbloom "pbloom"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(1)
+	}
+
+	switch os.Args[1] {
+	case "inspect":
+		if len(os.Args) != 3 {
+			fmt.Fprintln(os.Stderr, "usage: pbloom inspect <file>")
+			os.Exit(1)
+		}
+		if err := inspect(os.Args[2]); err != nil {
+			fmt.Fprintln(os.Stderr, "pbloom inspect:", err)
+			os.Exit(1)
+		}
+	default:
+		usage()
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: pbloom <command> [args]")
+	fmt.Fprintln(os.Stderr, "commands:")
+	fmt.Fprintln(os.Stderr, "  inspect <file>   print a binary-format filter's header and fill ratio")
+}
+
+// inspect prints a binary-format filter file's header fields and a quick
+// fill-ratio estimate.
+func inspect(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	info, err := pbloom.InspectBinary(data)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("version:     %d\n", info.Version)
+	fmt.Printf("kind:        %s\n", info.Kind)
+	fmt.Printf("hash family: %s\n", info.HashFamily)
+	fmt.Printf("k:           %d\n", info.K)
+	fmt.Printf("m (bits):    %d\n", info.M)
+	fmt.Printf("crc32c:      %08x (valid: %t)\n", info.CRC32C, info.CRCValid)
+	fmt.Printf("fill ratio:  %.4f\n", info.FillRatio)
+	return nil
+}
@@ -0,0 +1,145 @@
+package pbloom
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sync/atomic"
+
+	"github.com/twmb/murmur3"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// concurrentWireVersion is the version byte written by ConcurrentFilter's
+// Serialize, so a future change to the []uint64 packing can be detected by
+// FromSerializedConcurrent.
+const concurrentWireVersion = 1
+
+// ConcurrentFilter is a Bloom filter safe for concurrent use by many
+// goroutines without a mutex. Bits are packed into 64-bit words and set or
+// read with atomic.Uint64.Or/Load, so Put and Exists never need to
+// synchronize with each other.
+type ConcurrentFilter struct {
+	words []atomic.Uint64
+	k     uint8
+}
+
+// NewConcurrentFilterFromEntriesAndFP initializes a ConcurrentFilter with a
+// specified number of entries and desired false positive rate, using the
+// same sizing formula as NewFilterFromEntriesAndFP.
+func NewConcurrentFilterFromEntriesAndFP(entries int, fpRate float64) (*ConcurrentFilter, error) {
+	if entries <= 0 {
+		return nil, errors.New("number of entries must be positive")
+	}
+	if fpRate <= 0 || fpRate >= 1 {
+		return nil, errors.New("false positive rate must be between 0 and 1")
+	}
+
+	m := -float64(entries) * math.Log(fpRate) / (math.Pow(math.Log(2), 2))
+	// Round m up to the nearest multiple of 64 so it packs into whole words.
+	m = math.Ceil(m/64.0) * 64.0
+	numWords := uint64(m) / 64
+
+	k := math.Round((m / float64(entries)) * math.Log(2))
+
+	return &ConcurrentFilter{
+		words: make([]atomic.Uint64, numWords),
+		k:     uint8(k),
+	}, nil
+}
+
+// Put inserts a key into the ConcurrentFilter by atomically OR-ing the
+// appropriate bits into their words. Safe for concurrent use.
+func (f *ConcurrentFilter) Put(key []byte) {
+	M := uint64(len(f.words)) * 64
+	h1, h2 := murmur3.Sum128(key)
+	for i := uint64(0); i < uint64(f.k); i++ {
+		hash := (h1 + i*h2) % M
+		f.words[hash/64].Or(1 << (hash % 64))
+	}
+}
+
+// Exists checks whether a key is possibly in the ConcurrentFilter. Safe for
+// concurrent use, including concurrently with Put.
+func (f *ConcurrentFilter) Exists(key []byte) bool {
+	M := uint64(len(f.words)) * 64
+	h1, h2 := murmur3.Sum128(key)
+	for i := uint64(0); i < uint64(f.k); i++ {
+		hash := (h1 + i*h2) % M
+		if f.words[hash/64].Load()&(1<<(hash%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Serialize serializes the ConcurrentFilter into a byte slice using
+// MessagePack, tagged with kindConcurrent. The bit words are packed to
+// []byte via binary.LittleEndian and prefixed with concurrentWireVersion so
+// a future change to the word layout can be detected on load.
+func (f *ConcurrentFilter) Serialize() ([]byte, error) {
+	packed := make([]byte, len(f.words)*8)
+	for i := range f.words {
+		binary.LittleEndian.PutUint64(packed[i*8:], f.words[i].Load())
+	}
+
+	encoded := bytes.Buffer{}
+	enc := msgpack.NewEncoder(&encoded)
+	if err := enc.EncodeUint8(uint8(kindConcurrent)); err != nil {
+		return nil, err
+	}
+	if err := enc.EncodeUint8(concurrentWireVersion); err != nil {
+		return nil, err
+	}
+	if err := enc.EncodeBytes(packed); err != nil {
+		return nil, err
+	}
+	if err := enc.EncodeUint8(f.k); err != nil {
+		return nil, err
+	}
+	return encoded.Bytes(), nil
+}
+
+// FromSerializedConcurrent deserializes a ConcurrentFilter previously
+// produced by Serialize. It returns an error if data was tagged as a
+// different filter kind or carries an unrecognized wire version.
+func FromSerializedConcurrent(data []byte) (*ConcurrentFilter, error) {
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	kind, err := dec.DecodeUint8()
+	if err != nil {
+		return nil, err
+	}
+	if serializedKind(kind) != kindConcurrent {
+		return nil, fmt.Errorf("data is not a serialized ConcurrentFilter (kind %d)", kind)
+	}
+	version, err := dec.DecodeUint8()
+	if err != nil {
+		return nil, err
+	}
+	if version != concurrentWireVersion {
+		return nil, fmt.Errorf("unsupported ConcurrentFilter wire version %d", version)
+	}
+	packed, err := dec.DecodeBytes()
+	if err != nil {
+		return nil, err
+	}
+	k, err := dec.DecodeUint8()
+	if err != nil {
+		return nil, err
+	}
+	if len(packed)%8 != 0 {
+		return nil, errors.New("packed word data is not a multiple of 8 bytes")
+	}
+
+	words := make([]atomic.Uint64, len(packed)/8)
+	for i := range words {
+		words[i].Store(binary.LittleEndian.Uint64(packed[i*8:]))
+	}
+
+	return &ConcurrentFilter{
+		words: words,
+		k:     k,
+	}, nil
+}
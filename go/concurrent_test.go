@@ -0,0 +1,126 @@
+package pbloom
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNewConcurrentFilterFromEntriesAndFP tests the constructor.
+func TestNewConcurrentFilterFromEntriesAndFP(t *testing.T) {
+	tests := []struct {
+		name        string
+		entries     int
+		fpRate      float64
+		expectError bool
+	}{
+		{name: "ValidInput", entries: 1000, fpRate: 0.01, expectError: false},
+		{name: "ZeroEntries", entries: 0, fpRate: 0.01, expectError: true},
+		{name: "BadFPRate", entries: 1000, fpRate: 1.5, expectError: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := NewConcurrentFilterFromEntriesAndFP(tt.entries, tt.fpRate)
+			if tt.expectError {
+				assert.Error(t, err)
+				assert.Nil(t, filter)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, filter)
+			}
+		})
+	}
+}
+
+// TestConcurrentFilterPutExists tests basic Put/Exists behavior.
+func TestConcurrentFilterPutExists(t *testing.T) {
+	filter, err := NewConcurrentFilterFromEntriesAndFP(1000, 0.01)
+	assert.NoError(t, err)
+	assert.NotNil(t, filter)
+
+	filter.Put([]byte("apple"))
+	assert.True(t, filter.Exists([]byte("apple")))
+	assert.False(t, filter.Exists([]byte("not-inserted")))
+}
+
+// TestConcurrentFilterConcurrentPutExists exercises Put and Exists from
+// many goroutines simultaneously; run with -race to confirm there are no
+// data races.
+func TestConcurrentFilterConcurrentPutExists(t *testing.T) {
+	entries := 10000
+	filter, err := NewConcurrentFilterFromEntriesAndFP(entries, 0.01)
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 16; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := g; i < entries; i += 16 {
+				filter.Put(concurrentTestKey(i))
+				filter.Exists(concurrentTestKey(i))
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	for i := 0; i < entries; i++ {
+		assert.True(t, filter.Exists(concurrentTestKey(i)))
+	}
+}
+
+// TestConcurrentFilterSerializeAndDeserialize tests the Serialize and
+// FromSerializedConcurrent methods.
+func TestConcurrentFilterSerializeAndDeserialize(t *testing.T) {
+	filter, err := NewConcurrentFilterFromEntriesAndFP(1000, 0.01)
+	assert.NoError(t, err)
+
+	keys := []string{"alpha", "beta", "gamma"}
+	for _, key := range keys {
+		filter.Put([]byte(key))
+	}
+
+	data, err := filter.Serialize()
+	assert.NoError(t, err)
+
+	deserialized, err := FromSerializedConcurrent(data)
+	assert.NoError(t, err)
+	assert.NotNil(t, deserialized)
+	assert.Equal(t, filter.k, deserialized.k)
+
+	for _, key := range keys {
+		assert.True(t, deserialized.Exists([]byte(key)))
+	}
+
+	_, err = FromSerializedConcurrent([]byte("not a concurrent filter"))
+	assert.Error(t, err)
+}
+
+func concurrentTestKey(i int) []byte {
+	return []byte("concurrent_key_" + strconv.Itoa(i))
+}
+
+// BenchmarkConcurrentFilterPutExistsParallel benchmarks many goroutines
+// inserting and querying a ConcurrentFilter at once. Run with -race to
+// confirm the atomic word operations are safe for concurrent use.
+func BenchmarkConcurrentFilterPutExistsParallel(b *testing.B) {
+	filter, err := NewConcurrentFilterFromEntriesAndFP(1000000, 0.01)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			key := concurrentTestKey(i)
+			filter.Put(key)
+			filter.Exists(key)
+			i++
+		}
+	})
+}
@@ -0,0 +1,238 @@
+package pbloom
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/twmb/murmur3"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// CountingFilter is a Bloom filter whose bit slots are replaced with small
+// saturating counters, which allows Delete in addition to Put and Exists.
+// Counters are packed tightly: with the default 4-bit counters, two
+// counters share each byte.
+type CountingFilter struct {
+	counters    []byte
+	k           uint8
+	counterBits uint8
+	numSlots    uint64
+}
+
+// counterMax returns the saturating maximum value for a counter of the
+// given width.
+func counterMax(counterBits uint8) uint16 {
+	return uint16(1<<counterBits) - 1
+}
+
+// NewCountingFilterFromEntriesAndFP initializes a CountingFilter sized for
+// the given number of entries and desired false positive rate, using
+// counterBits bits per counter (4, 8, or 16).
+func NewCountingFilterFromEntriesAndFP(entries int, fpRate float64, counterBits uint8) (*CountingFilter, error) {
+	if entries <= 0 {
+		return nil, errors.New("number of entries must be positive")
+	}
+	if fpRate <= 0 || fpRate >= 1 {
+		return nil, errors.New("false positive rate must be between 0 and 1")
+	}
+	switch counterBits {
+	case 4, 8, 16:
+	default:
+		return nil, errors.New("counterBits must be 4, 8, or 16")
+	}
+
+	m := -float64(entries) * math.Log(fpRate) / (math.Pow(math.Log(2), 2))
+	// Round the slot count up to a multiple of 8 so that ToFilter's bit
+	// count lines up exactly with numSlots (Filter derives its modulus from
+	// len(bits)*8, which must equal numSlots for hash positions to agree).
+	numSlots := uint64(math.Ceil(m/8.0)) * 8
+	k := math.Round((m / float64(entries)) * math.Log(2))
+
+	numBytes := (numSlots*uint64(counterBits) + 7) / 8
+
+	return &CountingFilter{
+		counters:    make([]byte, numBytes),
+		k:           uint8(k),
+		counterBits: counterBits,
+		numSlots:    numSlots,
+	}, nil
+}
+
+// counter returns the value of the slot-th counter.
+func (f *CountingFilter) counter(slot uint64) uint16 {
+	switch f.counterBits {
+	case 4:
+		b := f.counters[slot/2]
+		if slot%2 == 0 {
+			return uint16(b & 0x0F)
+		}
+		return uint16(b >> 4)
+	case 8:
+		return uint16(f.counters[slot])
+	default: // 16
+		hi := f.counters[slot*2]
+		lo := f.counters[slot*2+1]
+		return uint16(hi)<<8 | uint16(lo)
+	}
+}
+
+// setCounter stores value into the slot-th counter, saturating at the
+// counter width's maximum.
+func (f *CountingFilter) setCounter(slot uint64, value uint16) {
+	max := counterMax(f.counterBits)
+	if value > max {
+		value = max
+	}
+	switch f.counterBits {
+	case 4:
+		i := slot / 2
+		if slot%2 == 0 {
+			f.counters[i] = (f.counters[i] &^ 0x0F) | byte(value&0x0F)
+		} else {
+			f.counters[i] = (f.counters[i] &^ 0xF0) | byte(value<<4)
+		}
+	case 8:
+		f.counters[slot] = byte(value)
+	default: // 16
+		f.counters[slot*2] = byte(value >> 8)
+		f.counters[slot*2+1] = byte(value)
+	}
+}
+
+// slots returns the k slot indices a key hashes to, derived from the
+// existing double-hashing scheme. Each call hashes with a fresh, stateless
+// murmur3.Sum128 rather than a shared hasher instance, so two goroutines
+// calling Put/Exists/Delete don't race on hasher state (the counters
+// themselves are still plain bytes, not atomics, so concurrent writes to
+// the same counter are still a data race; use ConcurrentFilter for that).
+func (f *CountingFilter) slots(key []byte) []uint64 {
+	h1, h2 := murmur3.Sum128(key)
+	slots := make([]uint64, f.k)
+	for i := uint64(0); i < uint64(f.k); i++ {
+		slots[i] = (h1 + i*h2) % f.numSlots
+	}
+	return slots
+}
+
+// Put inserts a key by incrementing each of its k counters, saturating
+// instead of wrapping around when a counter is already at its maximum.
+func (f *CountingFilter) Put(key []byte) {
+	max := counterMax(f.counterBits)
+	for _, slot := range f.slots(key) {
+		c := f.counter(slot)
+		if c < max {
+			f.setCounter(slot, c+1)
+		}
+	}
+}
+
+// Exists checks whether a key is possibly in the CountingFilter, i.e.
+// whether all k of its counters are non-zero.
+func (f *CountingFilter) Exists(key []byte) bool {
+	for _, slot := range f.slots(key) {
+		if f.counter(slot) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Delete removes a key by decrementing each of its k counters. A saturated
+// counter is left untouched, since it may be shared with another key whose
+// true count is unknown; decrementing it could introduce a false negative
+// for that key.
+func (f *CountingFilter) Delete(key []byte) {
+	max := counterMax(f.counterBits)
+	for _, slot := range f.slots(key) {
+		c := f.counter(slot)
+		if c > 0 && c < max {
+			f.setCounter(slot, c-1)
+		}
+	}
+}
+
+// ToFilter collapses the CountingFilter's counters down to a plain bit
+// Filter (a counter is "set" if it is non-zero), suitable for cheap
+// long-term storage once no further deletions are needed.
+func (f *CountingFilter) ToFilter() *Filter {
+	bits := make([]byte, (f.numSlots+7)/8)
+	for slot := uint64(0); slot < f.numSlots; slot++ {
+		if f.counter(slot) != 0 {
+			bits[slot/8] |= 1 << (slot % 8)
+		}
+	}
+	return &Filter{
+		bits: bits,
+		k:    f.k,
+	}
+}
+
+// Serialize serializes the CountingFilter into a byte slice using
+// MessagePack, tagged with kindCounting.
+func (f *CountingFilter) Serialize() ([]byte, error) {
+	encoded := bytes.Buffer{}
+	enc := msgpack.NewEncoder(&encoded)
+	if err := enc.EncodeUint8(uint8(kindCounting)); err != nil {
+		return nil, err
+	}
+	if err := enc.EncodeBytes(f.counters); err != nil {
+		return nil, err
+	}
+	if err := enc.EncodeUint8(f.k); err != nil {
+		return nil, err
+	}
+	if err := enc.EncodeUint8(f.counterBits); err != nil {
+		return nil, err
+	}
+	if err := enc.EncodeUint64(f.numSlots); err != nil {
+		return nil, err
+	}
+	return encoded.Bytes(), nil
+}
+
+// FromSerializedCounting deserializes a CountingFilter previously produced
+// by Serialize. It returns an error if data was tagged as a different
+// filter kind.
+func FromSerializedCounting(data []byte) (*CountingFilter, error) {
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	kind, err := dec.DecodeUint8()
+	if err != nil {
+		return nil, err
+	}
+	if serializedKind(kind) != kindCounting {
+		return nil, fmt.Errorf("data is not a serialized CountingFilter (kind %d)", kind)
+	}
+	counters, err := dec.DecodeBytes()
+	if err != nil {
+		return nil, err
+	}
+	k, err := dec.DecodeUint8()
+	if err != nil {
+		return nil, err
+	}
+	counterBits, err := dec.DecodeUint8()
+	if err != nil {
+		return nil, err
+	}
+	numSlots, err := dec.DecodeUint64()
+	if err != nil {
+		return nil, err
+	}
+	switch counterBits {
+	case 4, 8, 16:
+	default:
+		return nil, fmt.Errorf("counterBits must be 4, 8, or 16, got %d", counterBits)
+	}
+	wantBytes := (numSlots*uint64(counterBits) + 7) / 8
+	if uint64(len(counters)) != wantBytes {
+		return nil, fmt.Errorf("counters length %d does not match numSlots %d and counterBits %d (want %d)", len(counters), numSlots, counterBits, wantBytes)
+	}
+	return &CountingFilter{
+		counters:    counters,
+		k:           k,
+		counterBits: counterBits,
+		numSlots:    numSlots,
+	}, nil
+}
@@ -0,0 +1,178 @@
+package pbloom
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// TestNewCountingFilterFromEntriesAndFP tests the NewCountingFilterFromEntriesAndFP constructor.
+func TestNewCountingFilterFromEntriesAndFP(t *testing.T) {
+	tests := []struct {
+		name        string
+		entries     int
+		fpRate      float64
+		counterBits uint8
+		expectError bool
+	}{
+		{name: "ValidInput", entries: 1000, fpRate: 0.01, counterBits: 4, expectError: false},
+		{name: "ValidInput8Bit", entries: 1000, fpRate: 0.01, counterBits: 8, expectError: false},
+		{name: "ZeroEntries", entries: 0, fpRate: 0.01, counterBits: 4, expectError: true},
+		{name: "BadFPRate", entries: 1000, fpRate: 1.5, counterBits: 4, expectError: true},
+		{name: "BadCounterBits", entries: 1000, fpRate: 0.01, counterBits: 3, expectError: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := NewCountingFilterFromEntriesAndFP(tt.entries, tt.fpRate, tt.counterBits)
+			if tt.expectError {
+				assert.Error(t, err)
+				assert.Nil(t, filter)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, filter)
+			}
+		})
+	}
+}
+
+// TestCountingFilterPutExistsDelete tests the insert -> exists -> delete -> not-exists lifecycle.
+func TestCountingFilterPutExistsDelete(t *testing.T) {
+	filter, err := NewCountingFilterFromEntriesAndFP(100, 0.01, 4)
+	assert.NoError(t, err)
+	assert.NotNil(t, filter)
+
+	key := []byte("apple")
+
+	assert.False(t, filter.Exists(key), "key should not exist before insertion")
+
+	filter.Put(key)
+	assert.True(t, filter.Exists(key), "key should exist after insertion")
+
+	filter.Delete(key)
+	assert.False(t, filter.Exists(key), "key should not exist after deletion")
+}
+
+// TestCountingFilterSaturation tests that repeated inserts saturate rather than wrap around.
+func TestCountingFilterSaturation(t *testing.T) {
+	filter, err := NewCountingFilterFromEntriesAndFP(100, 0.01, 4)
+	assert.NoError(t, err)
+	assert.NotNil(t, filter)
+
+	key := []byte("saturate-me")
+
+	// 4-bit counters saturate at 15; insert well past that.
+	for i := 0; i < 100; i++ {
+		filter.Put(key)
+	}
+	assert.True(t, filter.Exists(key))
+
+	// Deleting as many times as we inserted shouldn't bring a saturated
+	// counter back down to zero.
+	for i := 0; i < 100; i++ {
+		filter.Delete(key)
+	}
+	assert.True(t, filter.Exists(key), "a saturated counter should not be fully undone by deletes")
+}
+
+// TestCountingFilterToFilter tests collapsing a CountingFilter down to a plain Filter.
+func TestCountingFilterToFilter(t *testing.T) {
+	counting, err := NewCountingFilterFromEntriesAndFP(100, 0.01, 4)
+	assert.NoError(t, err)
+	assert.NotNil(t, counting)
+
+	keys := []string{"one", "two", "three"}
+	for _, key := range keys {
+		counting.Put([]byte(key))
+	}
+
+	plain := counting.ToFilter()
+	assert.NotNil(t, plain)
+	for _, key := range keys {
+		assert.True(t, plain.Exists([]byte(key)))
+	}
+}
+
+// TestCountingFilterSerializeAndDeserialize tests the Serialize and FromSerializedCounting methods.
+func TestCountingFilterSerializeAndDeserialize(t *testing.T) {
+	filter, err := NewCountingFilterFromEntriesAndFP(1000, 0.01, 4)
+	assert.NoError(t, err)
+	assert.NotNil(t, filter)
+
+	keys := []string{"alpha", "beta", "gamma"}
+	for _, key := range keys {
+		filter.Put([]byte(key))
+	}
+
+	data, err := filter.Serialize()
+	assert.NoError(t, err)
+
+	deserialized, err := FromSerializedCounting(data)
+	assert.NoError(t, err)
+	assert.NotNil(t, deserialized)
+	assert.Equal(t, filter.k, deserialized.k)
+	assert.Equal(t, filter.counterBits, deserialized.counterBits)
+	assert.Equal(t, filter.counters, deserialized.counters)
+
+	for _, key := range keys {
+		assert.True(t, deserialized.Exists([]byte(key)))
+	}
+
+	_, err = FromSerializedCounting([]byte("not a counting filter"))
+	assert.Error(t, err)
+}
+
+// TestCountingFilterFromSerializedRejectsCorruption tests that
+// FromSerializedCounting validates counterBits and cross-checks the
+// counters length against numSlots/counterBits, instead of accepting a
+// payload that would later panic in counter()/setCounter().
+func TestCountingFilterFromSerializedRejectsCorruption(t *testing.T) {
+	filter, err := NewCountingFilterFromEntriesAndFP(1000, 0.01, 4)
+	assert.NoError(t, err)
+	filter.Put([]byte("apple"))
+
+	data, err := filter.Serialize()
+	assert.NoError(t, err)
+
+	t.Run("BadCounterBits", func(t *testing.T) {
+		corrupted := rebuildCountingPayload(t, data, 3, filter.numSlots)
+		_, err := FromSerializedCounting(corrupted)
+		assert.Error(t, err)
+	})
+
+	t.Run("NumSlotsTooLarge", func(t *testing.T) {
+		corrupted := rebuildCountingPayload(t, data, filter.counterBits, filter.numSlots*100)
+		_, err := FromSerializedCounting(corrupted)
+		assert.Error(t, err)
+	})
+}
+
+// rebuildCountingPayload decodes data with the real decoder (bypassing
+// FromSerializedCounting's own validation) and re-encodes it with
+// counterBits and numSlots replaced.
+func rebuildCountingPayload(t *testing.T, data []byte, counterBits uint8, numSlots uint64) []byte {
+	t.Helper()
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	_, err := dec.DecodeUint8()
+	assert.NoError(t, err)
+	counters, err := dec.DecodeBytes()
+	assert.NoError(t, err)
+	k, err := dec.DecodeUint8()
+	assert.NoError(t, err)
+	_, err = dec.DecodeUint8() // original counterBits, discarded
+	assert.NoError(t, err)
+	_, err = dec.DecodeUint64() // original numSlots, discarded
+	assert.NoError(t, err)
+
+	encoded := bytes.Buffer{}
+	enc := msgpack.NewEncoder(&encoded)
+	assert.NoError(t, enc.EncodeUint8(uint8(kindCounting)))
+	assert.NoError(t, enc.EncodeBytes(counters))
+	assert.NoError(t, enc.EncodeUint8(k))
+	assert.NoError(t, enc.EncodeUint8(counterBits))
+	assert.NoError(t, enc.EncodeUint64(numSlots))
+	return encoded.Bytes()
+}
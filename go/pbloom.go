@@ -3,18 +3,31 @@ package pbloom
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"math"
 
-	"github.com/spaolacci/murmur3"
+	"github.com/twmb/murmur3"
 	"github.com/vmihailenco/msgpack/v5"
 )
 
 type Filter struct {
-	bits   []byte
-	k      uint8
-	hasher murmur3.Hash128
+	bits []byte
+	k    uint8
 }
 
+// serializedKind tags a serialized payload with the concrete filter type it
+// encodes, so FromSerialized (and its sibling FromSerializedX functions) can
+// tell filter variants apart and reject a mismatched one.
+type serializedKind uint8
+
+const (
+	kindPlain serializedKind = iota
+	kindBlocked
+	kindCounting
+	kindScalable
+	kindConcurrent
+)
+
 // NewFilterFromEntriesAndSize initializes a Bloom filter with a specified number of entries and storage size in bytes.
 // It calculates the optimal number of hash functions (K) based on the provided parameters.
 func NewFilterFromEntriesAndSize(entries int, size int) (*Filter, error) {
@@ -29,9 +42,8 @@ func NewFilterFromEntriesAndSize(entries int, size int) (*Filter, error) {
 	k := math.Ceil((m / float64(entries)) * math.Log(2))
 
 	return &Filter{
-		bits:   make([]byte, size),
-		k:      uint8(k),
-		hasher: murmur3.New128(),
+		bits: make([]byte, size),
+		k:    uint8(k),
 	}, nil
 }
 
@@ -55,9 +67,8 @@ func NewFilterFromEntriesAndFP(entries int, fpRate float64) (*Filter, error) {
 	k := math.Round((m / float64(entries)) * math.Log(2))
 
 	return &Filter{
-		bits:   make([]byte, size),
-		k:      uint8(k),
-		hasher: murmur3.New128(),
+		bits: make([]byte, size),
+		k:    uint8(k),
 	}, nil
 }
 
@@ -72,14 +83,60 @@ func NewFilterFromBits(bits []byte, k uint8) (*Filter, error) {
 	}
 
 	return &Filter{
-		bits:   bits,
-		k:      k,
-		hasher: murmur3.New128(),
+		bits: bits,
+		k:    k,
 	}, nil
 }
 
-// FromSerialized deserializes a Bloom filter from a byte slice using MessagePack.
+// FromSerialized deserializes a Bloom filter from a byte slice using
+// MessagePack. It understands both the current kind-tagged payload (see
+// Filter.Serialize) and the original untagged (bits, k) payload, so filters
+// serialized before the tag was introduced still decode.
 func FromSerialized(data []byte) (*Filter, error) {
+	if len(data) == 0 {
+		return nil, errors.New("data cannot be empty")
+	}
+	if isUntaggedPayload(data) {
+		return fromSerializedUntagged(data)
+	}
+
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	kind, err := dec.DecodeUint8()
+	if err != nil {
+		return nil, err
+	}
+	if serializedKind(kind) != kindPlain {
+		return nil, fmt.Errorf("data is not a serialized Filter (kind %d)", kind)
+	}
+	bits, err := dec.DecodeBytes()
+	if err != nil {
+		return nil, err
+	}
+	k, err := dec.DecodeUint8()
+	if err != nil {
+		return nil, err
+	}
+	return &Filter{
+		bits: bits,
+		k:    k,
+	}, nil
+}
+
+// isUntaggedPayload reports whether data starts with the original (bits, k)
+// payload rather than a kind tag. The original payload always begins with a
+// msgpack-encoded byte slice (a bin8/bin16/bin32 header), while a kind tag
+// is written with EncodeUint8 and so always begins with the msgpack uint8
+// header (0xcc), so the leading byte alone disambiguates the two formats.
+func isUntaggedPayload(data []byte) bool {
+	const (
+		msgpackBin8  = 0xc4
+		msgpackBin32 = 0xc6
+	)
+	return data[0] >= msgpackBin8 && data[0] <= msgpackBin32
+}
+
+// fromSerializedUntagged decodes the original, pre-tag (bits, k) payload.
+func fromSerializedUntagged(data []byte) (*Filter, error) {
 	dec := msgpack.NewDecoder(bytes.NewReader(data))
 	bits, err := dec.DecodeBytes()
 	if err != nil {
@@ -90,18 +147,15 @@ func FromSerialized(data []byte) (*Filter, error) {
 		return nil, err
 	}
 	return &Filter{
-		bits:   bits,
-		k:      k,
-		hasher: murmur3.New128(),
+		bits: bits,
+		k:    k,
 	}, nil
 }
 
 // Put inserts a key into the Bloom filter by setting the appropriate bits.
 func (f *Filter) Put(key []byte) {
 	M := uint64(len(f.bits) * 8)
-	f.hasher.Reset()
-	f.hasher.Write(key)
-	h1, h2 := f.hasher.Sum128()
+	h1, h2 := murmur3.Sum128(key)
 	for i := uint64(0); i < uint64(f.k); i++ {
 		hash := (h1 + i*h2) % M
 		f.bits[hash/8] |= 1 << (hash % 8)
@@ -112,9 +166,7 @@ func (f *Filter) Put(key []byte) {
 // Returns true if the key might be in the set, or false if it is definitely not present.
 func (f *Filter) Exists(key []byte) bool {
 	M := uint64(len(f.bits) * 8)
-	f.hasher.Reset()
-	f.hasher.Write(key)
-	h1, h2 := f.hasher.Sum128()
+	h1, h2 := murmur3.Sum128(key)
 	for i := uint64(0); i < uint64(f.k); i++ {
 		hash := (h1 + i*h2) % M
 		if f.bits[hash/8]&(1<<(hash%8)) == 0 {
@@ -124,10 +176,15 @@ func (f *Filter) Exists(key []byte) bool {
 	return true
 }
 
-// Serialize serializes the Bloom filter into a byte slice using MessagePack.
+// Serialize serializes the Bloom filter into a byte slice using MessagePack,
+// tagged with kindPlain so FromSerialized can tell it apart from other
+// filter variants (e.g. BlockedFilter).
 func (f *Filter) Serialize() ([]byte, error) {
 	encoded := bytes.Buffer{}
 	enc := msgpack.NewEncoder(&encoded)
+	if err := enc.EncodeUint8(uint8(kindPlain)); err != nil {
+		return nil, err
+	}
 	if err := enc.EncodeBytes(f.bits); err != nil {
 		return nil, err
 	}
@@ -0,0 +1,251 @@
+package pbloom
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// defaultGrowthFactor and defaultTighteningRatio match the parameters used
+// in Almeida et al.'s scalable Bloom filter paper.
+const (
+	defaultGrowthFactor    = 2.0
+	defaultTighteningRatio = 0.8
+)
+
+// scalableLayer is one inner filter in a ScalableFilter, together with the
+// capacity and false positive rate it was sized for.
+type scalableLayer struct {
+	filter   *Filter
+	capacity int
+	fp       float64
+	count    int
+}
+
+// ScalableFilter is a Bloom filter that grows automatically as it fills up,
+// following the scalable Bloom filter design of Almeida et al. It wraps a
+// growing slice of inner layers: once the newest layer is estimated to be
+// full, a new, larger layer with a tighter false positive rate is appended
+// so the compounded global false positive rate stays bounded.
+type ScalableFilter struct {
+	layers          []*scalableLayer
+	growthFactor    float64
+	tighteningRatio float64
+}
+
+// NewScalableFilter creates a ScalableFilter whose first layer is sized for
+// initialEntries at the given false positive rate. growthFactor scales each
+// subsequent layer's capacity (default 2) and tighteningRatio (0, 1) tightens
+// each subsequent layer's false positive rate (default 0.8) so the
+// compounded false positive rate across all layers stays close to fp.
+func NewScalableFilter(initialEntries int, fp float64, growthFactor float64, tighteningRatio float64) (*ScalableFilter, error) {
+	if initialEntries <= 0 {
+		return nil, errors.New("initial number of entries must be positive")
+	}
+	if fp <= 0 || fp >= 1 {
+		return nil, errors.New("false positive rate must be between 0 and 1")
+	}
+	if growthFactor <= 1 {
+		return nil, errors.New("growth factor must be greater than 1")
+	}
+	if tighteningRatio <= 0 || tighteningRatio >= 1 {
+		return nil, errors.New("tightening ratio must be between 0 and 1")
+	}
+
+	// The compounded false positive rate across all layers is a geometric
+	// series p0 + p0*r + p0*r^2 + ... = p0/(1-r), so the first layer must
+	// target p0 = fp*(1-r) for the series to converge to fp overall.
+	firstLayerFP := fp * (1 - tighteningRatio)
+	first, err := newScalableLayer(initialEntries, firstLayerFP)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ScalableFilter{
+		layers:          []*scalableLayer{first},
+		growthFactor:    growthFactor,
+		tighteningRatio: tighteningRatio,
+	}, nil
+}
+
+// newScalableLayer constructs a scalableLayer sized for capacity entries at
+// the given false positive rate.
+func newScalableLayer(capacity int, fp float64) (*scalableLayer, error) {
+	filter, err := NewFilterFromEntriesAndFP(capacity, fp)
+	if err != nil {
+		return nil, err
+	}
+	return &scalableLayer{filter: filter, capacity: capacity, fp: fp}, nil
+}
+
+// currentLayer returns the newest layer, growing the filter with a new
+// layer first if the newest layer has reached its designed capacity.
+func (f *ScalableFilter) currentLayer() *scalableLayer {
+	last := f.layers[len(f.layers)-1]
+	if last.count < last.capacity {
+		return last
+	}
+
+	nextCapacity := int(float64(last.capacity) * f.growthFactor)
+	nextFP := last.fp * f.tighteningRatio
+	// growthFactor > 1 and tighteningRatio in (0, 1) are enforced by
+	// NewScalableFilter, so nextCapacity and nextFP are always valid.
+	next, _ := newScalableLayer(nextCapacity, nextFP)
+	f.layers = append(f.layers, next)
+	return next
+}
+
+// Put inserts a key into the newest layer, growing the filter first if
+// needed.
+func (f *ScalableFilter) Put(key []byte) {
+	layer := f.currentLayer()
+	layer.filter.Put(key)
+	layer.count++
+}
+
+// Exists checks whether a key is possibly in the ScalableFilter by checking
+// every layer; a key found in any layer is reported present.
+func (f *ScalableFilter) Exists(key []byte) bool {
+	for _, layer := range f.layers {
+		if layer.filter.Exists(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// Len returns the total number of keys inserted across all layers.
+func (f *ScalableFilter) Len() int {
+	total := 0
+	for _, layer := range f.layers {
+		total += layer.count
+	}
+	return total
+}
+
+// Capacity returns the total designed capacity across all layers before
+// another layer would need to be added.
+func (f *ScalableFilter) Capacity() int {
+	total := 0
+	for _, layer := range f.layers {
+		total += layer.capacity
+	}
+	return total
+}
+
+// Serialize serializes the ScalableFilter into a byte slice using
+// MessagePack, tagged with kindScalable. Each layer is encoded as its
+// (bits, k, capacity, fp, count) tuple.
+func (f *ScalableFilter) Serialize() ([]byte, error) {
+	encoded := bytes.Buffer{}
+	enc := msgpack.NewEncoder(&encoded)
+	if err := enc.EncodeUint8(uint8(kindScalable)); err != nil {
+		return nil, err
+	}
+	if err := enc.EncodeFloat64(f.growthFactor); err != nil {
+		return nil, err
+	}
+	if err := enc.EncodeFloat64(f.tighteningRatio); err != nil {
+		return nil, err
+	}
+	if err := enc.EncodeArrayLen(len(f.layers)); err != nil {
+		return nil, err
+	}
+	for _, layer := range f.layers {
+		if err := enc.EncodeBytes(layer.filter.bits); err != nil {
+			return nil, err
+		}
+		if err := enc.EncodeUint8(layer.filter.k); err != nil {
+			return nil, err
+		}
+		if err := enc.EncodeInt(int64(layer.capacity)); err != nil {
+			return nil, err
+		}
+		if err := enc.EncodeFloat64(layer.fp); err != nil {
+			return nil, err
+		}
+		if err := enc.EncodeInt(int64(layer.count)); err != nil {
+			return nil, err
+		}
+	}
+	return encoded.Bytes(), nil
+}
+
+// FromSerializedScalable deserializes a ScalableFilter previously produced
+// by Serialize. It returns an error if data was tagged as a different
+// filter kind; this keeps old single-layer Filter blobs (tagged kindPlain,
+// or entirely untagged) decoding only through FromSerialized, as before.
+func FromSerializedScalable(data []byte) (*ScalableFilter, error) {
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	kind, err := dec.DecodeUint8()
+	if err != nil {
+		return nil, err
+	}
+	if serializedKind(kind) != kindScalable {
+		return nil, fmt.Errorf("data is not a serialized ScalableFilter (kind %d)", kind)
+	}
+	growthFactor, err := dec.DecodeFloat64()
+	if err != nil {
+		return nil, err
+	}
+	if growthFactor <= 1 {
+		return nil, errors.New("growth factor must be greater than 1")
+	}
+	tighteningRatio, err := dec.DecodeFloat64()
+	if err != nil {
+		return nil, err
+	}
+	if tighteningRatio <= 0 || tighteningRatio >= 1 {
+		return nil, errors.New("tightening ratio must be between 0 and 1")
+	}
+	numLayers, err := dec.DecodeArrayLen()
+	if err != nil {
+		return nil, err
+	}
+
+	layers := make([]*scalableLayer, 0, numLayers)
+	for i := 0; i < numLayers; i++ {
+		bits, err := dec.DecodeBytes()
+		if err != nil {
+			return nil, err
+		}
+		k, err := dec.DecodeUint8()
+		if err != nil {
+			return nil, err
+		}
+		capacity, err := dec.DecodeInt()
+		if err != nil {
+			return nil, err
+		}
+		if capacity <= 0 {
+			return nil, fmt.Errorf("layer %d capacity must be positive, got %d", i, capacity)
+		}
+		fp, err := dec.DecodeFloat64()
+		if err != nil {
+			return nil, err
+		}
+		if fp <= 0 || fp >= 1 {
+			return nil, fmt.Errorf("layer %d false positive rate must be between 0 and 1, got %f", i, fp)
+		}
+		count, err := dec.DecodeInt()
+		if err != nil {
+			return nil, err
+		}
+		if count < 0 {
+			return nil, fmt.Errorf("layer %d count must not be negative, got %d", i, count)
+		}
+		filter, err := NewFilterFromBits(bits, k)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, &scalableLayer{filter: filter, capacity: capacity, fp: fp, count: count})
+	}
+
+	return &ScalableFilter{
+		layers:          layers,
+		growthFactor:    growthFactor,
+		tighteningRatio: tighteningRatio,
+	}, nil
+}
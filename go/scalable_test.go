@@ -0,0 +1,169 @@
+package pbloom
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// TestNewScalableFilter tests the NewScalableFilter constructor.
+func TestNewScalableFilter(t *testing.T) {
+	tests := []struct {
+		name            string
+		initialEntries  int
+		fp              float64
+		growthFactor    float64
+		tighteningRatio float64
+		expectError     bool
+	}{
+		{name: "ValidInput", initialEntries: 1000, fp: 0.01, growthFactor: 2, tighteningRatio: 0.8, expectError: false},
+		{name: "ZeroEntries", initialEntries: 0, fp: 0.01, growthFactor: 2, tighteningRatio: 0.8, expectError: true},
+		{name: "BadFP", initialEntries: 1000, fp: 1.5, growthFactor: 2, tighteningRatio: 0.8, expectError: true},
+		{name: "BadGrowthFactor", initialEntries: 1000, fp: 0.01, growthFactor: 1, tighteningRatio: 0.8, expectError: true},
+		{name: "BadTighteningRatio", initialEntries: 1000, fp: 0.01, growthFactor: 2, tighteningRatio: 1.0, expectError: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := NewScalableFilter(tt.initialEntries, tt.fp, tt.growthFactor, tt.tighteningRatio)
+			if tt.expectError {
+				assert.Error(t, err)
+				assert.Nil(t, filter)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, filter)
+				assert.Equal(t, 1, len(filter.layers))
+			}
+		})
+	}
+}
+
+// TestScalableFilterGrows tests that inserting past the initial capacity
+// grows the filter with additional layers while keeping the observed false
+// positive rate within bounds of the configured target.
+func TestScalableFilterGrows(t *testing.T) {
+	initialEntries := 1000
+	fp := 0.01
+	filter, err := NewScalableFilter(initialEntries, fp, defaultGrowthFactor, defaultTighteningRatio)
+	assert.NoError(t, err)
+	assert.NotNil(t, filter)
+
+	totalEntries := initialEntries * 5
+	for i := 0; i < totalEntries; i++ {
+		filter.Put(scalableTestKey(i))
+	}
+
+	assert.Greater(t, len(filter.layers), 1, "filter should have grown past the initial layer")
+	assert.Equal(t, totalEntries, filter.Len())
+
+	testSize := 10000
+	falsePositives := 0
+	for i := totalEntries; i < totalEntries+testSize; i++ {
+		if filter.Exists(scalableTestKey(i)) {
+			falsePositives++
+		}
+	}
+
+	actualFPRate := float64(falsePositives) / float64(testSize)
+	assert.LessOrEqual(t, actualFPRate, fp*1.5, "observed false positive rate should stay within 1.5x of target")
+}
+
+// TestScalableFilterSerializeAndDeserialize tests the Serialize and
+// FromSerializedScalable methods.
+func TestScalableFilterSerializeAndDeserialize(t *testing.T) {
+	filter, err := NewScalableFilter(100, 0.01, defaultGrowthFactor, defaultTighteningRatio)
+	assert.NoError(t, err)
+	assert.NotNil(t, filter)
+
+	for i := 0; i < 500; i++ {
+		filter.Put(scalableTestKey(i))
+	}
+
+	data, err := filter.Serialize()
+	assert.NoError(t, err)
+
+	deserialized, err := FromSerializedScalable(data)
+	assert.NoError(t, err)
+	assert.NotNil(t, deserialized)
+	assert.Equal(t, len(filter.layers), len(deserialized.layers))
+	assert.Equal(t, filter.Len(), deserialized.Len())
+
+	for i := 0; i < 500; i++ {
+		assert.True(t, deserialized.Exists(scalableTestKey(i)))
+	}
+}
+
+// TestScalableFilterFromSerializedRejectsCorruption tests that
+// FromSerializedScalable re-validates growthFactor and tighteningRatio,
+// instead of accepting a payload that would later grow a nil layer and
+// panic in Put.
+func TestScalableFilterFromSerializedRejectsCorruption(t *testing.T) {
+	filter, err := NewScalableFilter(100, 0.01, defaultGrowthFactor, defaultTighteningRatio)
+	assert.NoError(t, err)
+	filter.Put([]byte("apple"))
+
+	data, err := filter.Serialize()
+	assert.NoError(t, err)
+
+	t.Run("GrowthFactorTooSmall", func(t *testing.T) {
+		corrupted := rebuildScalableHeader(t, data, 0.5, defaultTighteningRatio)
+		_, err := FromSerializedScalable(corrupted)
+		assert.Error(t, err)
+	})
+
+	t.Run("TighteningRatioOutOfRange", func(t *testing.T) {
+		corrupted := rebuildScalableHeader(t, data, defaultGrowthFactor, 1.5)
+		_, err := FromSerializedScalable(corrupted)
+		assert.Error(t, err)
+	})
+}
+
+// rebuildScalableHeader decodes data with the real decoder (bypassing
+// FromSerializedScalable's own validation) and re-encodes it with
+// growthFactor and tighteningRatio replaced, leaving the layers untouched.
+func rebuildScalableHeader(t *testing.T, data []byte, growthFactor, tighteningRatio float64) []byte {
+	t.Helper()
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	_, err := dec.DecodeUint8()
+	assert.NoError(t, err)
+	_, err = dec.DecodeFloat64() // original growthFactor, discarded
+	assert.NoError(t, err)
+	_, err = dec.DecodeFloat64() // original tighteningRatio, discarded
+	assert.NoError(t, err)
+	numLayers, err := dec.DecodeArrayLen()
+	assert.NoError(t, err)
+
+	encoded := bytes.Buffer{}
+	enc := msgpack.NewEncoder(&encoded)
+	assert.NoError(t, enc.EncodeUint8(uint8(kindScalable)))
+	assert.NoError(t, enc.EncodeFloat64(growthFactor))
+	assert.NoError(t, enc.EncodeFloat64(tighteningRatio))
+	assert.NoError(t, enc.EncodeArrayLen(numLayers))
+	for i := 0; i < numLayers; i++ {
+		bits, err := dec.DecodeBytes()
+		assert.NoError(t, err)
+		k, err := dec.DecodeUint8()
+		assert.NoError(t, err)
+		capacity, err := dec.DecodeInt()
+		assert.NoError(t, err)
+		fp, err := dec.DecodeFloat64()
+		assert.NoError(t, err)
+		count, err := dec.DecodeInt()
+		assert.NoError(t, err)
+
+		assert.NoError(t, enc.EncodeBytes(bits))
+		assert.NoError(t, enc.EncodeUint8(k))
+		assert.NoError(t, enc.EncodeInt(int64(capacity)))
+		assert.NoError(t, enc.EncodeFloat64(fp))
+		assert.NoError(t, enc.EncodeInt(int64(count)))
+	}
+	return encoded.Bytes()
+}
+
+func scalableTestKey(i int) []byte {
+	return []byte("scalable_key_" + strconv.Itoa(i))
+}